@@ -0,0 +1,369 @@
+package editor
+
+import (
+	"sort"
+
+	"github.com/therecipe/qt/core"
+	"github.com/therecipe/qt/widgets"
+)
+
+// Cursor is one insertion point in a Buffer. A Buffer always has at
+// least one Cursor (its main one); additional cursors are created by
+// multi-cursor commands.
+type Cursor struct {
+	row         int
+	col         int
+	x           int
+	y           int
+	scrollCol   int
+	lastVisualX int
+	main        bool
+
+	selectionAnchorRow int
+	selectionAnchorCol int
+	hasSelection       bool
+}
+
+// cursorList returns the buffer's cursor list, creating a main cursor
+// at (0, 0) if the buffer has none yet.
+func (b *Buffer) cursorList() []*Cursor {
+	if len(b.cursors) == 0 {
+		b.cursors = []*Cursor{{main: true}}
+	}
+	return b.cursors
+}
+
+// mainCursor returns the buffer's main cursor.
+func (b *Buffer) mainCursor() *Cursor {
+	for _, c := range b.cursorList() {
+		if c.main {
+			return c
+		}
+	}
+	return b.cursors[0]
+}
+
+// AddCursorAbove spawns a new cursor one buffer line above the main
+// cursor, at the same (clamped) column.
+func (w *Window) AddCursorAbove() {
+	w.addCursorVertical(-1)
+}
+
+// AddCursorBelow spawns a new cursor one buffer line below the main
+// cursor, at the same (clamped) column.
+func (w *Window) AddCursorBelow() {
+	w.addCursorVertical(1)
+}
+
+func (w *Window) addCursorVertical(dRow int) {
+	main := w.buffer.mainCursor()
+	row, col := w.validPos(main.row+dRow, main.col)
+	w.addCursor(row, col)
+}
+
+// MoveCursorVertical moves c by dRow buffer lines, preserving c's own
+// remembered horizontal position (c.scrollCol) across the move, the way
+// the window-level scrollCol does for the main cursor during
+// single-cursor vertical motion, so secondary cursors don't collapse
+// onto column 0 after passing through a short line.
+func (w *Window) MoveCursorVertical(c *Cursor, dRow int) {
+	row, col := w.validPos(c.row+dRow, c.scrollCol)
+	w.setPosCursor(c, row, col, false)
+}
+
+// SetCursorColumn moves c to col on its current row and records col as
+// the column MoveCursorVertical should return to.
+func (w *Window) SetCursorColumn(c *Cursor, col int) {
+	row, col := w.validPos(c.row, col)
+	c.scrollCol = col
+	w.setPosCursor(c, row, col, false)
+}
+
+// addCursor creates a new, non-main cursor at (row, col), merging with
+// an existing cursor already at that position instead of duplicating it.
+func (w *Window) addCursor(row, col int) *Cursor {
+	for _, c := range w.buffer.cursorList() {
+		if c.row == row && c.col == col {
+			return c
+		}
+	}
+	c := &Cursor{row: row, col: col, scrollCol: col}
+	x, y := w.getPos(row, col)
+	c.x, c.y = x, y
+	w.buffer.cursors = append(w.buffer.cursors, c)
+	w.mergeCursors()
+	return c
+}
+
+// AddCursorAtNextOccurrence spawns a new cursor at the next occurrence
+// of the word under the main cursor, searching forward from it.
+func (w *Window) AddCursorAtNextOccurrence() {
+	word := w.wordUnderCursor()
+	if word == "" {
+		return
+	}
+	main := w.buffer.mainCursor()
+	row, col := w.findNext(word, main.row, main.col)
+	if row == -1 {
+		return
+	}
+	w.addCursor(row, col)
+}
+
+// AddCursorsAtAllMatches spawns a cursor at every match of the main
+// cursor's selection, or the word under it if it has no selection, in
+// the buffer.
+func (w *Window) AddCursorsAtAllMatches() {
+	word := w.selectionTextOrWordUnderCursor(w.buffer.mainCursor())
+	if word == "" {
+		return
+	}
+	row, col := 0, -1
+	for {
+		foundRow, foundCol := w.findNext(word, row, col)
+		if foundRow == -1 {
+			break
+		}
+		w.addCursor(foundRow, foundCol)
+		row, col = foundRow, foundCol+len([]rune(word))-1
+		if row >= len(w.buffer.lines) {
+			break
+		}
+	}
+}
+
+// selectionTextOrWordUnderCursor returns the text of c's selection, or
+// the word under c if it has none.
+func (w *Window) selectionTextOrWordUnderCursor(c *Cursor) string {
+	startRow, startCol, endRow, endCol, ok := c.Selection()
+	if !ok {
+		return w.WordUnderCursor(c)
+	}
+	if startRow != endRow {
+		// Multi-line selections aren't a single fuzzy-matchable string;
+		// fall back to the word under the cursor like having no
+		// selection at all.
+		return w.WordUnderCursor(c)
+	}
+	line := w.buffer.lines[startRow]
+	if line == nil {
+		return ""
+	}
+	text := []rune(line.text)
+	if startCol < 0 || endCol > len(text) || startCol >= endCol {
+		return ""
+	}
+	return string(text[startCol:endCol])
+}
+
+// findNext searches forward from (row, col) for word and returns its
+// position, or (-1, -1) if it isn't found anywhere in the buffer.
+func (w *Window) findNext(word string, row, col int) (int, int) {
+	runeWord := []rune(word)
+	for r := row; r < len(w.buffer.lines); r++ {
+		line := w.buffer.lines[r]
+		if line == nil {
+			continue
+		}
+		text := []rune(line.text)
+		start := 0
+		if r == row {
+			start = col + 1
+		}
+		for c := start; c+len(runeWord) <= len(text); c++ {
+			if string(text[c:c+len(runeWord)]) == word {
+				return r, c
+			}
+		}
+	}
+	return -1, -1
+}
+
+// mergeCursors removes duplicate cursors that ended up at the same
+// (row, col), keeping the main cursor when it's one of the duplicates,
+// and leaves the remaining cursors sorted by buffer position.
+func (w *Window) mergeCursors() {
+	seen := map[[2]int]*Cursor{}
+	merged := make([]*Cursor, 0, len(w.buffer.cursors))
+	for _, c := range w.buffer.cursors {
+		key := [2]int{c.row, c.col}
+		if existing, ok := seen[key]; ok {
+			if c.main {
+				existing.main = true
+			}
+			continue
+		}
+		seen[key] = c
+		merged = append(merged, c)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		if merged[i].row != merged[j].row {
+			return merged[i].row < merged[j].row
+		}
+		return merged[i].col < merged[j].col
+	})
+	w.buffer.cursors = merged
+}
+
+// StartSelection anchors c's selection at its current position. Moving
+// c afterwards extends the selection from there; call ClearSelection to
+// drop it back to a plain insertion point.
+func (c *Cursor) StartSelection() {
+	c.selectionAnchorRow = c.row
+	c.selectionAnchorCol = c.col
+	c.hasSelection = true
+}
+
+// ClearSelection drops c's selection, if it has one.
+func (c *Cursor) ClearSelection() {
+	c.hasSelection = false
+}
+
+// Selection returns c's selection as an ordered (startRow, startCol,
+// endRow, endCol) range running from its anchor to its current
+// position, and false if c has no active selection.
+func (c *Cursor) Selection() (startRow, startCol, endRow, endCol int, ok bool) {
+	if !c.hasSelection {
+		return 0, 0, 0, 0, false
+	}
+	if c.selectionAnchorRow < c.row || (c.selectionAnchorRow == c.row && c.selectionAnchorCol <= c.col) {
+		return c.selectionAnchorRow, c.selectionAnchorCol, c.row, c.col, true
+	}
+	return c.row, c.col, c.selectionAnchorRow, c.selectionAnchorCol, true
+}
+
+// ClearExtraCursors collapses the buffer back down to just its main
+// cursor.
+func (w *Window) ClearExtraCursors() {
+	main := w.buffer.mainCursor()
+	main.main = true
+	w.buffer.cursors = []*Cursor{main}
+	w.updateClines()
+}
+
+// setPosCursor is the per-cursor core of setPos: it positions c at
+// (row, col) and, for the main cursor, keeps the window's legacy
+// row/col/x/y fields and the xi view in sync.
+func (w *Window) setPosCursor(c *Cursor, row, col int, toXi bool) {
+	b := w.buffer
+	x, y := b.getPosVisual(row, col, w.wrapWidth())
+	c.x = x - w.horizontalScrollValue
+	c.y = y - w.verticalScrollValue
+	c.row = row
+	c.col = col
+	_, c.lastVisualX = w.visualIndex(row, col)
+
+	if c.main {
+		oldX, oldY := w.x, w.y
+		w.x, w.y = c.x, c.y
+		w.row, w.col = row, col
+		if toXi {
+			if w.workspace.selection {
+				b.xiView.Drag(row, col)
+			} else {
+				b.xiView.Click(row, col)
+			}
+		}
+		w.start, w.end = w.scrollRegion()
+		w.setGutterShift()
+		w.updateCursor()
+		if oldX != w.x || oldY != w.y {
+			w.gutter.Update()
+			w.updateCline()
+		}
+	}
+	w.updateClines()
+}
+
+// updateClines repositions the cline highlight widgets so that every
+// non-main cursor's buffer line is also highlighted, mirroring what
+// updateCline does for the main cursor.
+func (w *Window) updateClines() {
+	cursors := w.buffer.cursorList()
+	want := len(cursors) - 1
+	for len(w.extraClines) < want {
+		cl := widgets.NewQWidget(nil, 0)
+		cl.SetParent(w.view)
+		cl.SetStyleSheet(w.editor.getClineStylesheet())
+		cl.SetFocusPolicy(core.Qt__NoFocus)
+		w.extraClines = append(w.extraClines, cl)
+	}
+	for len(w.extraClines) > want {
+		last := w.extraClines[len(w.extraClines)-1]
+		last.Hide()
+		w.extraClines = w.extraClines[:len(w.extraClines)-1]
+	}
+
+	i := 0
+	for _, c := range cursors {
+		if c.main {
+			continue
+		}
+		cl := w.extraClines[i]
+		cl.Resize2(w.frame.width, int(w.buffer.font.lineHeight))
+		cl.Move2(0, c.y)
+		cl.Show()
+		i++
+	}
+}
+
+// withCursorPos temporarily points w.row/w.col at c for the duration of
+// fn, then restores them. It lets the existing position-based helpers
+// (wordForward, wordEnd, wordUnderCursor, charUnderCursor) operate on
+// any cursor, not just the main one, without duplicating their logic.
+func (w *Window) withCursorPos(c *Cursor, fn func()) {
+	oldRow, oldCol := w.row, w.col
+	w.row, w.col = c.row, c.col
+	fn()
+	w.row, w.col = oldRow, oldCol
+}
+
+// CharUnderCursor returns the rune under c.
+func (w *Window) CharUnderCursor(c *Cursor) rune {
+	var r rune
+	w.withCursorPos(c, func() { r = w.charUnderCursor() })
+	return r
+}
+
+// WordUnderCursor returns the word under c.
+func (w *Window) WordUnderCursor(c *Cursor) string {
+	var s string
+	w.withCursorPos(c, func() { s = w.wordUnderCursor() })
+	return s
+}
+
+// WordForwardCursor returns the position count words forward from c.
+func (w *Window) WordForwardCursor(c *Cursor, count int) (row, col int) {
+	w.withCursorPos(c, func() { row, col = w.wordForward(count) })
+	return row, col
+}
+
+// WordEndCursor returns the position count word-ends forward from c.
+func (w *Window) WordEndCursor(c *Cursor, count int) (row, col int) {
+	w.withCursorPos(c, func() { row, col = w.wordEnd(count) })
+	return row, col
+}
+
+// NeedsScrollCursor is the per-cursor variant of needsScroll.
+func (w *Window) NeedsScrollCursor(c *Cursor) (int, int) {
+	return w.needsScroll(c.row, c.col)
+}
+
+// SetCursorsFromSelections replaces the buffer's cursor set with one
+// cursor per (row, col) position, as reported by a batched xi-view
+// selection update. The first position becomes the main cursor.
+func (w *Window) SetCursorsFromSelections(positions [][2]int) {
+	if len(positions) == 0 {
+		return
+	}
+	cursors := make([]*Cursor, 0, len(positions))
+	for i, pos := range positions {
+		c := &Cursor{row: pos[0], col: pos[1], main: i == 0}
+		x, y := w.getPos(c.row, c.col)
+		c.x, c.y = x, y
+		cursors = append(cursors, c)
+	}
+	w.buffer.cursors = cursors
+	w.mergeCursors()
+	w.setPosCursor(w.buffer.mainCursor(), w.buffer.mainCursor().row, w.buffer.mainCursor().col, false)
+}
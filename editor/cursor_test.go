@@ -0,0 +1,58 @@
+package editor
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newMatchTestWindow builds a minimal Window over a single-line buffer,
+// just enough to exercise findNext/AddCursorsAtAllMatches without any Qt
+// widgets.
+func newMatchTestWindow(text string) *Window {
+	b := &Buffer{
+		font:  &font{width: 8, lineHeight: 16},
+		lines: []*Line{{text: text}},
+	}
+	b.cursors = []*Cursor{{main: true}}
+	return &Window{buffer: b}
+}
+
+// selectCursor anchors and points c's selection at [startCol, endCol) on
+// row 0, the way StartSelection plus a move would leave it.
+func selectCursor(c *Cursor, startCol, endCol int) {
+	c.selectionAnchorRow, c.selectionAnchorCol = 0, startCol
+	c.row, c.col = 0, endCol
+	c.hasSelection = true
+}
+
+func TestAddCursorsAtAllMatchesAdjacent(t *testing.T) {
+	w := newMatchTestWindow("aaa")
+	selectCursor(w.buffer.mainCursor(), 0, 1)
+
+	w.AddCursorsAtAllMatches()
+
+	var got [][2]int
+	for _, c := range w.buffer.cursors {
+		got = append(got, [2]int{c.row, c.col})
+	}
+	want := [][2]int{{0, 0}, {0, 1}, {0, 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("cursors = %v, want %v", got, want)
+	}
+}
+
+func TestAddCursorsAtAllMatchesOverlappingWord(t *testing.T) {
+	w := newMatchTestWindow("foofoo")
+	selectCursor(w.buffer.mainCursor(), 0, 3)
+
+	w.AddCursorsAtAllMatches()
+
+	var got [][2]int
+	for _, c := range w.buffer.cursors {
+		got = append(got, [2]int{c.row, c.col})
+	}
+	want := [][2]int{{0, 0}, {0, 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("cursors = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,151 @@
+package editor
+
+import "github.com/therecipe/qt/gui"
+
+// Severity is the level of a gutter message, used to pick its glyph and
+// color when painting the gutter.
+type Severity int
+
+// Severity levels for gutter messages, ordered from least to most severe.
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// GutterMessage is a single diagnostic/linter message attached to a
+// buffer line by an owner such as "lsp" or "vet".
+type GutterMessage struct {
+	Owner string
+	Line  int
+	Kind  Severity
+	Text  string
+}
+
+// GutterMessages holds the messages attached to a buffer's lines, keyed
+// by owner so that e.g. LSP diagnostics and a linter can coexist and be
+// cleared independently.
+type GutterMessages struct {
+	messages map[string][]GutterMessage
+}
+
+func newGutterMessages() *GutterMessages {
+	return &GutterMessages{
+		messages: map[string][]GutterMessage{},
+	}
+}
+
+// AddGutterMessage attaches a message to line from owner. A later call
+// for the same owner and line replaces the previous message.
+func (b *Buffer) AddGutterMessage(owner string, line int, kind Severity, text string) {
+	if b.gutterMessages == nil {
+		b.gutterMessages = newGutterMessages()
+	}
+	msgs := b.gutterMessages.messages[owner]
+	for i, m := range msgs {
+		if m.Line == line {
+			msgs[i] = GutterMessage{Owner: owner, Line: line, Kind: kind, Text: text}
+			return
+		}
+	}
+	b.gutterMessages.messages[owner] = append(msgs, GutterMessage{
+		Owner: owner,
+		Line:  line,
+		Kind:  kind,
+		Text:  text,
+	})
+}
+
+// ClearGutterMessages removes every message previously added by owner.
+func (b *Buffer) ClearGutterMessages(owner string) {
+	if b.gutterMessages == nil {
+		return
+	}
+	delete(b.gutterMessages.messages, owner)
+}
+
+// messagesAtLine returns every message attached to line, across all
+// owners, most severe first.
+func (b *Buffer) messagesAtLine(line int) []GutterMessage {
+	if b.gutterMessages == nil {
+		return nil
+	}
+	var out []GutterMessage
+	for _, msgs := range b.gutterMessages.messages {
+		for _, m := range msgs {
+			if m.Line == line {
+				out = append(out, m)
+			}
+		}
+	}
+	for i := 1; i < len(out); i++ {
+		for j := i; j > 0 && out[j].Kind > out[j-1].Kind; j-- {
+			out[j], out[j-1] = out[j-1], out[j]
+		}
+	}
+	return out
+}
+
+// statusGutterMessage returns the highest-severity message on the
+// cursor's current line, for display in the status area, or nil if the
+// line has none.
+func (w *Window) statusGutterMessage() *GutterMessage {
+	msgs := w.buffer.messagesAtLine(w.row)
+	if len(msgs) == 0 {
+		return nil
+	}
+	return &msgs[0]
+}
+
+// gutterMessageGlyph returns the single-character glyph drawn in the
+// gutter's message band for the given severity.
+func gutterMessageGlyph(kind Severity) string {
+	switch kind {
+	case SeverityError:
+		return "●"
+	case SeverityWarning:
+		return "▲"
+	default:
+		return "■"
+	}
+}
+
+// gutterMessageColor returns the color used to paint a message glyph of
+// the given severity.
+func gutterMessageColor(kind Severity) *gui.QColor {
+	switch kind {
+	case SeverityError:
+		return gui.NewQColor3(224, 56, 56, 255)
+	case SeverityWarning:
+		return gui.NewQColor3(224, 168, 32, 255)
+	default:
+		return gui.NewQColor3(90, 150, 224, 255)
+	}
+}
+
+// ConnectGutterMessageClick registers hook to be called with the buffer
+// line number whenever the user clicks a gutter message glyph.
+func (w *Window) ConnectGutterMessageClick(hook func(line int)) {
+	w.gutterMessageClickHook = hook
+}
+
+// gutterMousePressEvent dispatches a click in the gutter's message band
+// to the registered hook, if one was clicked.
+func (w *Window) gutterMousePressEvent(event *gui.QMouseEvent) {
+	if w.gutterMessageClickHook == nil {
+		return
+	}
+	pos := event.Pos()
+	if pos.X() >= w.gutterMessageWidth {
+		return
+	}
+	i := int(float64(pos.Y())/w.buffer.font.lineHeight) + w.start
+	row := w.bufferRow(i)
+	if row < 0 || row >= len(w.buffer.lines) {
+		return
+	}
+	if len(w.buffer.messagesAtLine(row)) == 0 {
+		return
+	}
+	w.gutterMessageClickHook(row)
+}
@@ -0,0 +1,281 @@
+package editor
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// paletteScoreBatch is how many base items are scored between
+// incremental result deliveries, so a palette with many thousands of
+// candidates (e.g. every line of a big buffer) shows partial results
+// before the whole pass is done. Cancellation is checked before every
+// item, not only at batch boundaries.
+const paletteScoreBatch = 200
+
+// paletteKind selects what a Palette's entries represent, which in turn
+// decides what happens on Enter.
+type paletteKind int
+
+// Palette kinds.
+const (
+	paletteBuffers paletteKind = iota
+	paletteLines
+	paletteSymbols
+)
+
+// paletteItem is one entry in a palette. text is what's fuzzy-matched
+// against the query; row/col/win carry whatever is needed to jump to it
+// on Accept. score and match are filled in by SetQuery.
+type paletteItem struct {
+	text string
+	row  int // buffer row, for paletteLines/paletteSymbols
+	col  int // buffer col, for paletteSymbols
+	win  *Window
+
+	score int
+	match []int
+}
+
+// Palette is a fuzzy-finder overlay on top of a Window, used to jump to
+// an open buffer, a line in the current buffer, or a word/symbol
+// occurrence. Scoring runs on its own goroutine and streams results
+// incrementally via onResults; each SetQuery call cancels any scoring
+// still in flight for the previous query.
+type Palette struct {
+	win  *Window
+	kind paletteKind
+
+	base []paletteItem // every candidate, unfiltered
+
+	mu        sync.Mutex
+	results   []paletteItem // base filtered by query and ranked by score
+	cancel    chan struct{}
+	onResults func([]paletteItem)
+}
+
+// NewPalette creates an empty palette of kind over win; items should be
+// appended to its base before the first SetQuery call.
+func NewPalette(win *Window, kind paletteKind) *Palette {
+	return &Palette{win: win, kind: kind}
+}
+
+// NewBufferPalette builds a palette over every window currently open in
+// win's workspace, keyed by the open buffer's display name.
+func NewBufferPalette(win *Window) *Palette {
+	p := NewPalette(win, paletteBuffers)
+	for _, other := range win.workspace.wins {
+		if other.buffer == nil {
+			continue
+		}
+		p.base = append(p.base, paletteItem{text: other.buffer.name, win: other})
+	}
+	return p
+}
+
+// NewLinePalette builds a palette over every line of win's current
+// buffer.
+func NewLinePalette(win *Window) *Palette {
+	p := NewPalette(win, paletteLines)
+	for row, line := range win.buffer.lines {
+		if line == nil {
+			continue
+		}
+		p.base = append(p.base, paletteItem{text: line.text, row: row})
+	}
+	return p
+}
+
+// NewSymbolPalette builds a palette over every occurrence of word in
+// win's current buffer.
+func NewSymbolPalette(win *Window, word string) *Palette {
+	p := NewPalette(win, paletteSymbols)
+	row, col := 0, -1
+	for {
+		row, col = win.findNext(word, row, col)
+		if row == -1 {
+			break
+		}
+		p.base = append(p.base, paletteItem{text: word, row: row, col: col})
+	}
+	return p
+}
+
+// ConnectResults registers hook to be called with the ranked results
+// every time a query finishes, or is updated incrementally while a
+// large base is still being scored. hook runs on the scoring goroutine
+// (for an empty query, on the goroutine that called SetQuery), not the
+// UI one; a caller that touches widgets from it must marshal
+// back the way Window's smoothScroll updates do via their signal
+// channel.
+func (p *Palette) ConnectResults(hook func([]paletteItem)) {
+	p.onResults = hook
+}
+
+// SetQuery kicks off scoring every base item against query on its own
+// goroutine, cancelling any scoring still in flight for a previous
+// query. After every paletteScoreBatch base items, a ranked snapshot of
+// all matches found so far is delivered, and a final one when the pass
+// ends. An empty query skips scoring and delivers every base item, in
+// base order, before SetQuery returns.
+func (p *Palette) SetQuery(query string) {
+	if p.cancel != nil {
+		close(p.cancel)
+	}
+	cancel := make(chan struct{})
+	p.cancel = cancel
+
+	if query == "" {
+		p.setResults(append([]paletteItem{}, p.base...))
+		return
+	}
+
+	go func() {
+		results := make([]paletteItem, 0, len(p.base))
+		for i, item := range p.base {
+			select {
+			case <-cancel:
+				return
+			default:
+			}
+			score, match, ok := fuzzyScore(item.text, query)
+			if ok {
+				item.score = score
+				item.match = match
+				results = append(results, item)
+			}
+			if (i+1)%paletteScoreBatch == 0 {
+				p.publish(cancel, results)
+			}
+		}
+		p.publish(cancel, results)
+	}()
+}
+
+// publish sorts a snapshot of results and delivers it, unless cancel has
+// since fired for a newer query.
+func (p *Palette) publish(cancel chan struct{}, results []paletteItem) {
+	select {
+	case <-cancel:
+		return
+	default:
+	}
+	sorted := append([]paletteItem{}, results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].score != sorted[j].score {
+			return sorted[i].score > sorted[j].score
+		}
+		if len(sorted[i].text) != len(sorted[j].text) {
+			return len(sorted[i].text) < len(sorted[j].text)
+		}
+		return sorted[i].match[0] < sorted[j].match[0]
+	})
+	p.setResults(sorted)
+}
+
+// setResults stores results and, if one is registered, notifies the
+// onResults hook.
+func (p *Palette) setResults(results []paletteItem) {
+	p.mu.Lock()
+	p.results = results
+	hook := p.onResults
+	p.mu.Unlock()
+	if hook != nil {
+		hook(results)
+	}
+}
+
+// fuzzy match bonus/penalty weights, tuned like fzf's default algorithm.
+const (
+	fuzzyBonusBoundary    = 10
+	fuzzyBonusCamel       = 8
+	fuzzyBonusConsecutive = 5
+	fuzzyPenaltyGap       = 2
+	fuzzyPenaltyLeading   = 1
+)
+
+// fuzzyScore greedily matches query against candidate, left to right,
+// case-insensitively. It returns false if candidate doesn't contain
+// every query rune in order. Matches at word boundaries (start of
+// string, after '/', '_', '-', '.', or a camelCase transition) score a
+// bonus; gaps between matched positions and non-matching leading
+// characters are penalized.
+func fuzzyScore(candidate, query string) (score int, positions []int, ok bool) {
+	c := []rune(candidate)
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return 0, nil, true
+	}
+
+	qi := 0
+	lastMatch := -1
+	positions = make([]int, 0, len(q))
+	for i, r := range c {
+		if qi >= len(q) {
+			break
+		}
+		if unicode.ToLower(r) != q[qi] {
+			continue
+		}
+
+		bonus := 0
+		if i == 0 {
+			bonus += fuzzyBonusBoundary
+		} else {
+			prev := c[i-1]
+			if prev == '/' || prev == '_' || prev == '-' || prev == '.' {
+				bonus += fuzzyBonusBoundary
+			} else if unicode.IsLower(prev) && unicode.IsUpper(r) {
+				bonus += fuzzyBonusCamel
+			}
+		}
+		if lastMatch >= 0 {
+			gap := i - lastMatch - 1
+			if gap == 0 {
+				bonus += fuzzyBonusConsecutive
+			} else {
+				bonus -= gap * fuzzyPenaltyGap
+			}
+		} else {
+			bonus -= i * fuzzyPenaltyLeading
+		}
+
+		score += bonus
+		positions = append(positions, i)
+		lastMatch = i
+		qi++
+	}
+
+	if qi < len(q) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
+
+// Accept jumps to the result at index. Buffer results swap the window's
+// buffer; line and symbol results scroll the window to the target
+// position. It does not close the palette or clear its results.
+func (p *Palette) Accept(index int) {
+	results := p.Results()
+	if index < 0 || index >= len(results) {
+		return
+	}
+	item := results[index]
+	switch p.kind {
+	case paletteBuffers:
+		if item.win != nil && item.win.buffer != nil {
+			p.win.loadBuffer(item.win.buffer)
+		}
+	case paletteLines, paletteSymbols:
+		p.win.scrollToCursor(item.row, item.col, true)
+	}
+}
+
+// Results returns the current ranked, scored palette results for
+// rendering; match holds the matched rune indices to highlight.
+func (p *Palette) Results() []paletteItem {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.results
+}
@@ -0,0 +1,62 @@
+package editor
+
+// CenterCursor scrolls the view so the cursor's buffer line lands in the
+// middle of the visible region, without moving the cursor.
+func (w *Window) CenterCursor() {
+	visibleRows := int(float64(w.frame.height) / w.buffer.font.lineHeight)
+	row, _ := w.visualIndex(w.row, w.col)
+	w.scrollToRow(row - visibleRows/2)
+}
+
+// CursorToTop scrolls the view so the cursor's buffer line lands
+// padding rows from the top of the visible region, without moving the
+// cursor.
+func (w *Window) CursorToTop(padding int) {
+	row, _ := w.visualIndex(w.row, w.col)
+	w.scrollToRow(row - padding)
+}
+
+// CursorToBottom scrolls the view so the cursor's buffer line lands
+// padding rows from the bottom of the visible region, without moving
+// the cursor.
+func (w *Window) CursorToBottom(padding int) {
+	visibleRows := int(float64(w.frame.height) / w.buffer.font.lineHeight)
+	row, _ := w.visualIndex(w.row, w.col)
+	w.scrollToRow(row - visibleRows + 1 + padding)
+}
+
+// scrollToRow smooth-scrolls the view so that row targetRow is the
+// topmost visible row, clamping to valid scroll values. Rows are counted
+// the way scrollRegion counts them: buffer rows, or visual lines when
+// soft-wrap is on. The cursor itself does not move.
+func (w *Window) scrollToRow(targetRow int) {
+	start, _ := w.scrollRegion()
+	dRows := targetRow - start
+	if dRows == 0 {
+		return
+	}
+	dy := int(float64(dRows)*w.buffer.font.lineHeight + 0.5)
+	endy := dy + w.verticalScrollValue
+	if endy < 0 {
+		dy = -w.verticalScrollValue
+	} else if endy > w.verticalScrollMaxValue {
+		dy = w.verticalScrollMaxValue - w.verticalScrollValue
+	}
+	w.smoothScroll(0, dy, &SetPos{row: w.row, col: w.col, toXi: false}, false)
+}
+
+// ScrollAdjust clamps the current scroll so the last buffer line never
+// scrolls past the bottom padding. The window's resize handler invokes
+// it; a buffer that shrinks does not trigger it.
+func (w *Window) ScrollAdjust() {
+	lineHeight := w.buffer.font.lineHeight
+	maxScroll := int(float64(len(w.buffer.lines))*lineHeight) - w.frame.height + int(lineHeight)
+	if maxScroll < 0 {
+		maxScroll = 0
+	}
+	if w.verticalScrollValue > maxScroll {
+		w.verticalScrollBar.SetValue(maxScroll)
+		w.verticalScrollValue = maxScroll
+		w.setPos(w.row, w.col, false)
+	}
+}
@@ -52,6 +52,7 @@ type ScrollJob struct {
 type Window struct {
 	id               int
 	editor           *Editor
+	workspace        *Workspace
 	widget           *widgets.QWidget
 	gutter           *widgets.QWidget
 	gutterChars      int
@@ -85,14 +86,32 @@ type Window struct {
 	horizontalScrollMaxValue  int
 
 	scrollJob *ScrollJob
+
+	softWrap    bool
+	visualLines []visualLine
+	lastVisualX int
+
+	gutterMessageWidth     int
+	gutterMessageClickHook func(line int)
+
+	extraClines []*widgets.QWidget
 }
 
-// NewWindow creates a new window
+// NewWindow creates a new window in editor's default workspace. It is
+// kept for callers that haven't migrated to an explicit workspace yet;
+// new code should prefer NewWindowInWorkspace.
 func NewWindow(editor *Editor, frame *Frame) *Window {
-	editor.winsRWMutext.Lock()
+	return NewWindowInWorkspace(editor.defaultWorkspace(), frame)
+}
+
+// NewWindowInWorkspace creates a new window belonging to ws.
+func NewWindowInWorkspace(ws *Workspace, frame *Frame) *Window {
+	editor := ws.editor
+	ws.winsRWMutext.Lock()
 	w := &Window{
-		id:               editor.winIndex,
+		id:               ws.winIndex,
 		editor:           editor,
+		workspace:        ws,
 		frame:            frame,
 		view:             widgets.NewQGraphicsView(nil),
 		cline:            widgets.NewQWidget(nil, 0),
@@ -120,6 +139,7 @@ func NewWindow(editor *Editor, frame *Frame) *Window {
 	w.widget.SetLayout(layout)
 	w.gutter.SetFixedWidth(30)
 	w.gutter.ConnectPaintEvent(w.paintGutter)
+	w.gutter.ConnectMousePressEvent(w.gutterMousePressEvent)
 
 	w.signal.ConnectUpdateSignal(func() {
 		update := <-w.updates
@@ -149,34 +169,34 @@ func NewWindow(editor *Editor, frame *Frame) *Window {
 		w.viewWheel(event)
 	})
 	frame.win = w
-	editor.winIndex++
-	editor.wins[w.id] = w
-	editor.winsRWMutext.Unlock()
+	ws.winIndex++
+	ws.wins[w.id] = w
+	ws.winsRWMutext.Unlock()
 
 	// w.view.SetFrameShape(widgets.QFrame__NoFrame)
 	w.view.ConnectMousePressEvent(func(event *gui.QMouseEvent) {
-		editor.activeWin = w
-		editor.cursor.SetParent(w.view)
+		ws.activeWin = w
+		ws.cursor.SetParent(w.view)
 		w.view.MousePressEventDefault(event)
 	})
 	w.view.ConnectKeyPressEvent(func(event *gui.QKeyEvent) {
 		if w.buffer == nil {
 			return
 		}
-		state, ok := editor.states[editor.mode]
+		state, ok := ws.states[ws.mode]
 		if !ok {
 			return
 		}
 
 		key := editor.convertKey(event)
 		if key != "" {
-			keys := editor.keymap.lookup(key)
+			keys := ws.keymap.lookup(key)
 			if keys == nil {
 				state.setCmd(key)
 				state.execute()
 			} else {
 				for _, key := range keys {
-					state, ok := editor.states[editor.mode]
+					state, ok := ws.states[ws.mode]
 					if !ok {
 						return
 					}
@@ -203,7 +223,11 @@ func NewWindow(editor *Editor, frame *Frame) *Window {
 		w.frame.width = w.widget.Width()
 		w.frame.height = w.widget.Height()
 		w.cline.Resize2(w.frame.width, int(w.buffer.font.lineHeight))
+		if w.softWrap {
+			w.visualLines = w.buildVisualLines()
+		}
 		w.setScroll()
+		w.ScrollAdjust()
 		w.editor.topFrame.setPos(0, 0)
 	})
 	w.view.SetFocusPolicy(core.Qt__ClickFocus)
@@ -212,7 +236,7 @@ func NewWindow(editor *Editor, frame *Frame) *Window {
 	w.view.SetFrameStyle(0)
 	w.horizontalScrollBar = w.view.HorizontalScrollBar()
 	w.verticalScrollBar = w.view.VerticalScrollBar()
-	if editor.theme != nil {
+	if ws.theme != nil {
 		scrollBarStyleSheet := editor.getScrollbarStylesheet()
 		w.widget.SetStyleSheet(scrollBarStyleSheet)
 		w.verticalScrollBarWidth = w.verticalScrollBar.Width()
@@ -247,12 +271,13 @@ func (w *Window) update() {
 	start, end := w.scrollRegion()
 	b := w.buffer
 	for i := start; i <= end; i++ {
-		if i >= len(b.lines) {
+		row := w.bufferRow(i)
+		if row >= len(b.lines) {
 			break
 		}
-		if b.lines[i] != nil && b.lines[i].invalid {
-			b.lines[i].invalid = false
-			b.updateLine(i)
+		if b.lines[row] != nil && b.lines[row].invalid {
+			b.lines[row].invalid = false
+			b.updateLine(row)
 		}
 	}
 	if !w.gutterInit {
@@ -264,12 +289,30 @@ func (w *Window) update() {
 	}
 }
 
+// scrollRegion returns the range of visible rows. With soft-wrap disabled
+// these are buffer rows; with it enabled they are indices into
+// w.visualLines, since a buffer line may occupy more than one screen row.
 func (w *Window) scrollRegion() (int, int) {
 	start := int(float64(w.verticalScrollValue) / w.buffer.font.lineHeight)
 	end := start + int(float64(w.frame.height)/w.buffer.font.lineHeight+1)
+	if w.softWrap && end > len(w.visualLines) {
+		end = len(w.visualLines)
+	}
 	return start, end
 }
 
+// bufferRow translates a visible row (as returned by scrollRegion) into
+// the buffer line it belongs to.
+func (w *Window) bufferRow(i int) int {
+	if !w.softWrap {
+		return i
+	}
+	if i < 0 || i >= len(w.visualLines) {
+		return i
+	}
+	return w.visualLines[i].row
+}
+
 func (w *Window) charUnderCursor() rune {
 	for _, r := range w.buffer.lines[w.row].text[w.col:] {
 		return r
@@ -466,11 +509,11 @@ func (w *Window) updateCline() {
 }
 
 func (w *Window) updateCursor() {
-	if w.editor.activeWin != w {
+	if w.workspace.activeWin != w {
 		return
 	}
 	w.editor.updateCursorShape()
-	cursor := w.editor.cursor
+	cursor := w.workspace.cursor
 	cursor.Move2(w.x, w.y)
 	cursor.Hide()
 	cursor.Show()
@@ -486,8 +529,12 @@ func (w *Window) loadBuffer(buffer *Buffer) {
 	w.buffer = buffer
 	w.view.SetScene(buffer.scence)
 	w.gutterChars = len(strconv.Itoa(len(buffer.lines)))
-	w.gutterWidth = int(float64(w.gutterChars)*w.buffer.font.width+0.5) + w.gutterPadding*2
+	w.gutterMessageWidth = int(w.buffer.font.width*1.5 + 0.5)
+	w.gutterWidth = int(float64(w.gutterChars)*w.buffer.font.width+0.5) + w.gutterPadding*2 + w.gutterMessageWidth
 	w.gutter.SetFixedWidth(w.gutterWidth)
+	if w.softWrap {
+		w.visualLines = w.buildVisualLines()
+	}
 }
 
 func (w *Window) scrollValue(rows, cols int) (int, int) {
@@ -521,7 +568,7 @@ func (w *Window) scrollValue(rows, cols int) (int, int) {
 func (w *Window) needsScroll(row, col int) (int, int) {
 	lineHeight := w.buffer.font.lineHeight
 	lineHeightInt := int(lineHeight)
-	posx, posy := w.buffer.getPos(row, col)
+	posx, posy := w.buffer.getPosVisual(row, col, w.wrapWidth())
 	dx := 0
 	x := w.horizontalScrollBar.Value()
 	verticalScrollBarWidth := 0
@@ -594,10 +641,19 @@ func (w *Window) validPos(row, col int) (int, int) {
 }
 
 func (w *Window) smoothScrollStart(s *SmoothScroll) {
-	row := w.row + s.rows
-	col := w.col + s.cols
-	if s.cols == 0 {
-		col = w.scrollCol
+	var row, col int
+	if w.softWrap && s.cols == 0 {
+		// In soft-wrap mode, plain vertical motion moves by visual line,
+		// not buffer line, and restores the remembered visual column
+		// (lastVisualX) rather than a raw buffer column, so it behaves
+		// correctly across a line that wraps into several screen rows.
+		row, col = w.visualStep(s.rows)
+	} else {
+		row = w.row + s.rows
+		col = w.col + s.cols
+		if s.cols == 0 {
+			col = w.scrollCol
+		}
 	}
 
 	row, col = w.validPos(row, col)
@@ -608,6 +664,7 @@ func (w *Window) smoothScrollStart(s *SmoothScroll) {
 
 	if s.cols != 0 {
 		w.scrollCol = col
+		_, w.lastVisualX = w.visualIndex(row, col)
 	}
 
 	dx := 0
@@ -744,29 +801,7 @@ func (w *Window) smoothScroll(x, y int, setPos *SetPos, cursor bool) (chan struc
 }
 
 func (w *Window) setPos(row, col int, toXi bool) {
-	b := w.buffer
-	x, y := b.getPos(row, col)
-	oldX := w.x
-	oldY := w.y
-	w.x = x - w.horizontalScrollValue
-	w.y = y - w.verticalScrollValue
-	w.row = row
-	w.col = col
-	if toXi {
-		if w.editor.selection {
-			b.xiView.Drag(w.row, w.col)
-		} else {
-			b.xiView.Click(w.row, w.col)
-		}
-	}
-	w.start, w.end = w.scrollRegion()
-	w.setGutterShift()
-	w.updateCursor()
-	if oldX == w.x && oldY == w.y {
-		return
-	}
-	w.gutter.Update()
-	w.updateCline()
+	w.setPosCursor(w.buffer.mainCursor(), row, col, toXi)
 }
 
 func (w *Window) outAfterScroll(dx, dy int) bool {
@@ -805,7 +840,7 @@ func (w *Window) outAfterScroll(dx, dy int) bool {
 }
 
 func (w *Window) getPos(row, col int) (int, int) {
-	x, y := w.buffer.getPos(row, col)
+	x, y := w.buffer.getPosVisual(row, col, w.wrapWidth())
 	x = x - w.horizontalScrollValue
 	y = y - w.verticalScrollValue
 	return x, y
@@ -845,7 +880,7 @@ func (w *Window) scroll(rows, cols int, cursor bool, scroll bool) {
 // if cursor is true, move the cursor in the view as well
 func (w *Window) scrollToCursor(row, col int, cursor bool) {
 	lineHeight := w.buffer.font.lineHeight
-	if !w.editor.smoothScroll {
+	if !w.workspace.smoothScroll {
 		x, y := w.buffer.getPos(row, col)
 		w.view.EnsureVisible2(
 			float64(x),
@@ -894,28 +929,39 @@ func (w *Window) paintGutter(event *gui.QPaintEvent) {
 	p := gui.NewQPainter2(w.gutter)
 	defer p.DestroyQPainter()
 	p.SetFont(w.buffer.font.font)
-	fg := w.editor.theme.Theme.Selection
+	fg := w.workspace.theme.Theme.Selection
 	fgColor := gui.NewQColor3(fg.R, fg.G, fg.B, fg.A)
-	clineFg := w.editor.theme.Theme.Foreground
+	clineFg := w.workspace.theme.Theme.Foreground
 	clineColor := gui.NewQColor3(clineFg.R, clineFg.G, clineFg.B, clineFg.A)
 	shift := w.gutterShift
 	for i := w.start; i < w.end; i++ {
-		if i >= len(w.buffer.lines) {
+		row := w.bufferRow(i)
+		if row >= len(w.buffer.lines) {
 			return
 		}
-		if i == w.row {
+		if w.softWrap && w.visualLines[i].offset != 0 {
+			// continuation of a wrapped line: no line number
+			continue
+		}
+		if row == w.row {
 			p.SetPen2(clineColor)
 		} else {
 			p.SetPen2(fgColor)
 		}
 
-		n := i + 1
+		n := row + 1
 		// if relative {
-		if w.row != i {
-			n = Abs(i - w.row)
+		if w.row != row {
+			n = Abs(row - w.row)
 		}
 		// }
-		padding := w.gutterPadding + int((w.buffer.font.fontMetrics.Width(strconv.Itoa(len(w.buffer.lines)))-w.buffer.font.fontMetrics.Width(strconv.Itoa(n)))+0.5)
-		p.DrawText3(padding, (i-w.start)*int(w.buffer.font.lineHeight)+shift, strconv.Itoa(n))
+		padding := w.gutterMessageWidth + w.gutterPadding + int((w.buffer.font.fontMetrics.Width(strconv.Itoa(len(w.buffer.lines)))-w.buffer.font.fontMetrics.Width(strconv.Itoa(n)))+0.5)
+		y := (i-w.start)*int(w.buffer.font.lineHeight) + shift
+		p.DrawText3(padding, y, strconv.Itoa(n))
+
+		if msgs := w.buffer.messagesAtLine(row); len(msgs) > 0 {
+			p.SetPen2(gutterMessageColor(msgs[0].Kind))
+			p.DrawText3(w.gutterPadding/2, y, gutterMessageGlyph(msgs[0].Kind))
+		}
 	}
 }
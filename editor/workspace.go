@@ -0,0 +1,120 @@
+package editor
+
+import (
+	"sync"
+
+	"github.com/therecipe/qt/widgets"
+)
+
+// Workspace is one independent xi-editor session: its own set of
+// windows, mode/state machine, keymap, active window and cursor widget.
+// Window, Buffer and Frame are threaded a *Workspace instead of
+// reaching into the package-level Editor singleton, so a process can
+// host more than one workspace at a time (e.g. side-by-side workspaces,
+// an embedded preview), each switching mode and keymap independently.
+// A Cursor holds no *Workspace of its own; it reaches one only through
+// the Window that moves it.
+type Workspace struct {
+	editor *Editor
+
+	winsRWMutext sync.RWMutex
+	wins         map[int]*Window
+	winIndex     int
+
+	states map[string]*State
+	mode   string
+	keymap *Keymap
+
+	activeWin *Window
+	cursor    *widgets.QWidget
+
+	theme     *Theme
+	selection bool
+
+	smoothScroll bool
+}
+
+// newWorkspace creates a Workspace backed by editor, seeded with
+// editor's current mode/states/keymap/cursor/theme/selection/
+// smoothScroll so a workspace created from an already-configured Editor
+// (the common case: setup runs, then the first window is created)
+// starts up fully usable instead of empty.
+func newWorkspace(editor *Editor) *Workspace {
+	return &Workspace{
+		editor:       editor,
+		wins:         map[int]*Window{},
+		states:       editor.states,
+		mode:         editor.mode,
+		keymap:       editor.keymap,
+		cursor:       editor.cursor,
+		theme:        editor.theme,
+		selection:    editor.selection,
+		smoothScroll: editor.smoothScroll,
+	}
+}
+
+// defaultWorkspace returns the Editor's backward-compatible single
+// workspace, creating it on first use. Code that hasn't been migrated
+// to explicit workspaces keeps working against it unchanged.
+func (e *Editor) defaultWorkspace() *Workspace {
+	if e.workspace == nil {
+		e.workspace = newWorkspace(e)
+	}
+	return e.workspace
+}
+
+// SetTheme sets the editor's theme and, if a default workspace has
+// already been created, keeps it in sync. Editor setup code should call
+// this instead of assigning e.theme directly so windows created through
+// the legacy NewWindow path see the change.
+func (e *Editor) SetTheme(theme *Theme) {
+	e.theme = theme
+	if e.workspace != nil {
+		e.workspace.theme = theme
+	}
+}
+
+// SetMode sets the editor's mode and keeps the default workspace, if
+// any, in sync. See SetTheme.
+func (e *Editor) SetMode(mode string) {
+	e.mode = mode
+	if e.workspace != nil {
+		e.workspace.mode = mode
+	}
+}
+
+// SetKeymap sets the editor's keymap and keeps the default workspace, if
+// any, in sync. See SetTheme.
+func (e *Editor) SetKeymap(keymap *Keymap) {
+	e.keymap = keymap
+	if e.workspace != nil {
+		e.workspace.keymap = keymap
+	}
+}
+
+// SetCursor sets the editor's cursor widget and keeps the default
+// workspace, if any, in sync. See SetTheme.
+func (e *Editor) SetCursor(cursor *widgets.QWidget) {
+	e.cursor = cursor
+	if e.workspace != nil {
+		e.workspace.cursor = cursor
+	}
+}
+
+// SetSelection sets the editor's selection mode and keeps the default
+// workspace, if any, in sync. See SetTheme.
+func (e *Editor) SetSelection(selection bool) {
+	e.selection = selection
+	if e.workspace != nil {
+		e.workspace.selection = selection
+	}
+}
+
+// SetSmoothScroll sets the editor's smooth-scroll setting and keeps the
+// default workspace, if any, in sync. See SetTheme.
+func (e *Editor) SetSmoothScroll(smoothScroll bool) {
+	e.smoothScroll = smoothScroll
+	if e.workspace != nil {
+		e.workspace.smoothScroll = smoothScroll
+	}
+}
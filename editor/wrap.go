@@ -0,0 +1,104 @@
+package editor
+
+// visualLine maps a screen row to a position in the buffer when soft-wrap
+// is enabled: a buffer line longer than the wrap width is split into
+// consecutive visualLines that share the same row but increasing offset.
+type visualLine struct {
+	row    int // buffer row
+	offset int // rune offset into the buffer line where this segment starts
+}
+
+// wrapWidth returns the number of columns available for text in the view,
+// excluding the gutter, or 0 if soft-wrap is disabled.
+func (w *Window) wrapWidth() int {
+	if !w.softWrap {
+		return 0
+	}
+	cols := int(float64(w.frame.width-w.gutterWidth) / w.buffer.font.width)
+	if cols < 1 {
+		cols = 1
+	}
+	return cols
+}
+
+// buildVisualLines rebuilds the screen-row -> (buffer row, offset) table
+// using the window's current wrap width. It is recomputed whenever
+// soft-wrap is toggled or the window is resized.
+func (w *Window) buildVisualLines() []visualLine {
+	width := w.wrapWidth()
+	lines := make([]visualLine, 0, len(w.buffer.lines))
+	for row, line := range w.buffer.lines {
+		if width <= 0 || line == nil || len(line.text) == 0 {
+			lines = append(lines, visualLine{row: row})
+			continue
+		}
+		text := []rune(line.text)
+		for offset := 0; offset < len(text); offset += width {
+			lines = append(lines, visualLine{row: row, offset: offset})
+		}
+	}
+	return lines
+}
+
+// visualIndex returns the index into w.visualLines of the segment holding
+// (row, col) - i.e. the one with offset <= col < offset+width - and the
+// visual column of col within that segment.
+func (w *Window) visualIndex(row, col int) (index int, x int) {
+	if !w.softWrap {
+		return row, col
+	}
+	width := w.wrapWidth()
+	for i, vl := range w.visualLines {
+		if vl.row != row {
+			continue
+		}
+		index = i
+		x = col - vl.offset
+		if width <= 0 || col < vl.offset+width {
+			return i, x
+		}
+	}
+	return index, x
+}
+
+// visualStep returns the buffer (row, col) reached by moving n visual
+// lines (negative for up) from the window's current position, restoring
+// its remembered visual column (w.lastVisualX) in the target segment
+// rather than reusing col as-is, so motion stays correct when the
+// current and target segments have different offsets.
+func (w *Window) visualStep(n int) (row, col int) {
+	if len(w.visualLines) == 0 {
+		return w.row, w.col
+	}
+	index, _ := w.visualIndex(w.row, w.col)
+	index += n
+	if index < 0 {
+		index = 0
+	} else if index >= len(w.visualLines) {
+		index = len(w.visualLines) - 1
+	}
+	vl := w.visualLines[index]
+	return vl.row, vl.offset + w.lastVisualX
+}
+
+// SetSoftWrap enables or disables soft-wrap for the window. Toggling it
+// resets horizontal scroll to 0 and rebuilds the visual-line table so
+// vertical motion stays correct across the switch.
+func (w *Window) SetSoftWrap(enabled bool) {
+	if w.softWrap == enabled {
+		return
+	}
+	w.softWrap = enabled
+	w.horizontalScrollValue = 0
+	w.horizontalScrollBar.SetValue(0)
+	w.visualLines = w.buildVisualLines()
+	_, w.lastVisualX = w.visualIndex(w.row, w.col)
+	w.setPos(w.row, w.col, false)
+	w.gutter.Update()
+}
+
+// ToggleSoftWrap flips the window's soft-wrap setting. It is the handler
+// bound to the soft-wrap keymap command.
+func (w *Window) ToggleSoftWrap() {
+	w.SetSoftWrap(!w.softWrap)
+}
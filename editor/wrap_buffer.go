@@ -0,0 +1,26 @@
+package editor
+
+// getPosVisual returns the pixel (x, y) position of (row, col) when lines
+// are soft-wrapped at wrapWidth columns, instead of the 1-to-1 buffer-row
+// mapping getPos uses. A wrapWidth of 0 disables wrapping and behaves like
+// getPos.
+func (b *Buffer) getPosVisual(row, col, wrapWidth int) (int, int) {
+	if wrapWidth <= 0 {
+		return b.getPos(row, col)
+	}
+	screenRow := 0
+	for r := 0; r < row; r++ {
+		n := 1
+		if b.lines[r] != nil {
+			if l := len([]rune(b.lines[r].text)); l > 0 {
+				n = (l + wrapWidth - 1) / wrapWidth
+			}
+		}
+		screenRow += n
+	}
+	screenRow += col / wrapWidth
+	visualCol := col % wrapWidth
+	x := int(float64(visualCol)*b.font.width + 0.5)
+	y := int(float64(screenRow)*b.font.lineHeight+0.5) + int(b.font.shift+0.5)
+	return x, y
+}
@@ -0,0 +1,35 @@
+package editor
+
+import "testing"
+
+func newWrapTestWindow(text string, wrapWidth int) *Window {
+	w := &Window{
+		softWrap: true,
+		frame:    &Frame{width: wrapWidth * 8},
+		buffer: &Buffer{
+			font:  &font{width: 8, lineHeight: 16},
+			lines: []*Line{{text: text}},
+		},
+	}
+	w.visualLines = w.buildVisualLines()
+	return w
+}
+
+// TestVisualStepWrappedLine covers up/down motion within a single buffer
+// line that has wrapped into several visual lines: visualStep should move
+// by visual row, landing on the segment above/below while keeping the
+// remembered visual column, not the buffer column the naive row+1 math
+// would produce.
+func TestVisualStepWrappedLine(t *testing.T) {
+	w := newWrapTestWindow("abcdefghij", 4) // wraps to "abcd" / "efgh" / "ij"
+
+	w.row, w.col = 0, 5 // second segment ("efgh"), visual col 1
+	_, w.lastVisualX = w.visualIndex(w.row, w.col)
+
+	if row, col := w.visualStep(1); row != 0 || col != 9 {
+		t.Fatalf("visualStep(1) = (%d, %d), want (0, 9)", row, col)
+	}
+	if row, col := w.visualStep(-1); row != 0 || col != 1 {
+		t.Fatalf("visualStep(-1) = (%d, %d), want (0, 1)", row, col)
+	}
+}